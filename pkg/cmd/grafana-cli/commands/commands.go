@@ -0,0 +1,9 @@
+package commands
+
+import "github.com/urfave/cli/v2"
+
+// Commands is the full list of grafana-cli subcommands, wired into the
+// urfave/cli App in cmd/grafana-cli/main.go as App.Commands.
+var Commands = []*cli.Command{
+	AlertingCommands,
+}
@@ -0,0 +1,51 @@
+package commands
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/urfave/cli/v2"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDefaultRenderTemplateFixture_IsValidTemplateData(t *testing.T) {
+	var v map[string]interface{}
+	require.NoError(t, json.Unmarshal([]byte(defaultRenderTemplateFixture), &v))
+	require.Equal(t, "firing", v["status"])
+	require.Len(t, v["alerts"], 2)
+}
+
+func TestRenderTemplateCommand_EndToEnd(t *testing.T) {
+	tmplFile := filepath.Join(t.TempDir(), "notify.tmpl")
+	tmplSource := `{{ range .Alerts.Firing }}ALERT={{ .Labels.alertname | toUpper }} DASHBOARD={{ .DashboardURL }} SILENCE={{ .SilenceURL }}
+{{ end }}`
+	require.NoError(t, os.WriteFile(tmplFile, []byte(tmplSource), 0o600))
+
+	var out bytes.Buffer
+	app := &cli.App{
+		Writer:   &out,
+		Commands: []*cli.Command{AlertingCommands},
+	}
+
+	err := app.Run([]string{"grafana-cli", "alerting", "render-template", "--templatefile", tmplFile, "--print-data"})
+	require.NoError(t, err)
+
+	rendered := out.String()
+	require.Contains(t, rendered, "ALERT=HIGHCPU")
+	require.Contains(t, rendered, "DASHBOARD=http://localhost:3000/d/abc123")
+	require.Contains(t, rendered, "SILENCE=")
+	require.Contains(t, rendered, "alertmanager=grafana")
+
+	// --print-data writes its JSON object before the rendered template text;
+	// decode just the leading JSON value and ignore the rendered text that
+	// follows it, rather than scanning for the last "}" in the output (which
+	// would break if rendered text itself ended a line with a brace).
+	var printed map[string]interface{}
+	require.NoError(t, json.NewDecoder(strings.NewReader(rendered)).Decode(&printed))
+	require.Equal(t, "firing", printed["status"])
+}
@@ -0,0 +1,136 @@
+package channels
+
+import (
+	"encoding/json"
+	"math"
+	"sort"
+
+	"github.com/prometheus/alertmanager/template"
+
+	ngmodels "github.com/grafana/grafana/pkg/services/ngalert/models"
+)
+
+// evalMatchesAnnotation is the richer, labels-aware companion to
+// ngmodels.ValuesAnnotation ("__values__"). It's optional: an eval engine
+// that doesn't emit it yet still works, since EvalMatches falls back to
+// deriving itself from __values__ alone.
+//
+// TODO: move alongside ngmodels.ValuesAnnotation once the eval engine emits it.
+const evalMatchesAnnotation = "__evalMatches__"
+
+// EvalMatch is one series' result from a single rule evaluation, in a form
+// templates can render without ad-hoc string parsing. Value is a pointer so
+// a NaN/no-data result can be distinguished from a real zero.
+type EvalMatch struct {
+	RefID  string      `json:"refID"`
+	Metric string      `json:"metric"`
+	Labels template.KV `json:"labels"`
+	Value  *float64    `json:"value"`
+}
+
+// parseEvalMatches builds the EvalMatch list for an alert from whichever
+// annotations are present: the richer evalMatchesAnnotation if the eval
+// engine emitted it, otherwise the older, label-less __values__ annotation.
+func parseEvalMatches(annotations template.KV, logger Logger) []EvalMatch {
+	if raw, ok := annotations[evalMatchesAnnotation]; ok {
+		var matches []EvalMatch
+		if err := json.Unmarshal([]byte(raw), &matches); err != nil {
+			logger.Warn("failed to unmarshal eval matches annotation", "error", err)
+		} else {
+			return matches
+		}
+	}
+
+	raw, ok := annotations[ngmodels.ValuesAnnotation]
+	if !ok {
+		return nil
+	}
+	var values map[string]float64
+	if err := json.Unmarshal([]byte(raw), &values); err != nil {
+		logger.Warn("failed to unmarshal values annotation", "error", err)
+		return nil
+	}
+
+	refIDs := make([]string, 0, len(values))
+	for refID := range values {
+		refIDs = append(refIDs, refID)
+	}
+	sort.Strings(refIDs)
+
+	matches := make([]EvalMatch, 0, len(refIDs))
+	for _, refID := range refIDs {
+		v := values[refID]
+		matches = append(matches, EvalMatch{RefID: refID, Value: &v})
+	}
+	return matches
+}
+
+// valuesFromEvalMatches derives the back-compat Values map from matches, for
+// callers still reading ExtendedAlert.Values directly. Returns nil if there
+// are no matches with a value, matching the old behavior of leaving Values
+// unset when there was no __values__ annotation to unmarshal.
+func valuesFromEvalMatches(matches []EvalMatch) map[string]float64 {
+	var values map[string]float64
+	for _, m := range matches {
+		if m.Value == nil {
+			continue
+		}
+		if values == nil {
+			values = make(map[string]float64, len(matches))
+		}
+		values[m.RefID] = *m.Value
+	}
+	return values
+}
+
+// ByRefID returns the subset of an alert's EvalMatches produced by the rule
+// query with the given RefID.
+func (a ExtendedAlert) ByRefID(refID string) []EvalMatch {
+	var out []EvalMatch
+	for _, m := range a.EvalMatches {
+		if m.RefID == refID {
+			out = append(out, m)
+		}
+	}
+	return out
+}
+
+// TopValuesByRefID returns the n EvalMatches with the highest Value across
+// all alerts, restricted to the given RefID, for rendering something like
+// "top 5 offending series" in a template. Matches with a nil or NaN Value
+// sort last and are excluded once real values fill the requested n.
+func (as ExtendedAlerts) TopValuesByRefID(refID string, n int) []EvalMatch {
+	var matches []EvalMatch
+	for _, a := range as {
+		matches = append(matches, a.ByRefID(refID)...)
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool {
+		vi, oki := comparableValue(matches[i].Value)
+		vj, okj := comparableValue(matches[j].Value)
+		if !oki {
+			return false
+		}
+		if !okj {
+			return true
+		}
+		return vi > vj
+	})
+
+	if n < 0 {
+		n = 0
+	}
+	if n < len(matches) {
+		matches = matches[:n]
+	}
+	return matches
+}
+
+// comparableValue reports whether v points to a usable (non-NaN) value, and
+// what it is.
+func comparableValue(v *float64) (float64, bool) {
+	if v == nil || math.IsNaN(*v) {
+		return 0, false
+	}
+	return *v, true
+}
@@ -0,0 +1,85 @@
+package channels
+
+import (
+	"math"
+	"testing"
+
+	"github.com/prometheus/alertmanager/template"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeLogger struct{}
+
+func (fakeLogger) Debug(string, ...interface{}) {}
+func (fakeLogger) Warn(string, ...interface{})  {}
+
+func ptr(f float64) *float64 { return &f }
+
+func TestParseEvalMatches_FallsBackToValuesAnnotation(t *testing.T) {
+	annotations := template.KV{"__values__": `{"A": 95.2, "B": -1.5}`}
+
+	matches := parseEvalMatches(annotations, fakeLogger{})
+	require.Len(t, matches, 2)
+	require.Equal(t, "A", matches[0].RefID)
+	require.Equal(t, ptr(95.2), matches[0].Value)
+	require.Equal(t, "B", matches[1].RefID)
+	require.Equal(t, ptr(-1.5), matches[1].Value)
+}
+
+func TestParseEvalMatches_PrefersRichAnnotation(t *testing.T) {
+	annotations := template.KV{
+		"__values__":          `{"A": 1}`,
+		evalMatchesAnnotation: `[{"refID":"A","metric":"up","labels":{"instance":"web-1"},"value":0.0}]`,
+	}
+
+	matches := parseEvalMatches(annotations, fakeLogger{})
+	require.Len(t, matches, 1)
+	require.Equal(t, EvalMatch{RefID: "A", Metric: "up", Labels: template.KV{"instance": "web-1"}, Value: ptr(0)}, matches[0])
+}
+
+func TestParseEvalMatches_NaNValue(t *testing.T) {
+	annotations := template.KV{
+		evalMatchesAnnotation: `[{"refID":"A","metric":"up","value":null}]`,
+	}
+
+	matches := parseEvalMatches(annotations, fakeLogger{})
+	require.Len(t, matches, 1)
+	require.Nil(t, matches[0].Value)
+}
+
+func TestValuesFromEvalMatches_SkipsNilValues(t *testing.T) {
+	matches := []EvalMatch{
+		{RefID: "A", Value: ptr(1)},
+		{RefID: "B", Value: nil},
+	}
+	require.Equal(t, map[string]float64{"A": 1}, valuesFromEvalMatches(matches))
+}
+
+func TestExtendedAlert_ByRefID(t *testing.T) {
+	a := ExtendedAlert{EvalMatches: []EvalMatch{
+		{RefID: "A", Metric: "up"},
+		{RefID: "B", Metric: "down"},
+		{RefID: "A", Metric: "up2"},
+	}}
+
+	got := a.ByRefID("A")
+	require.Len(t, got, 2)
+	require.Equal(t, "up", got[0].Metric)
+	require.Equal(t, "up2", got[1].Metric)
+}
+
+func TestExtendedAlerts_TopValuesByRefID(t *testing.T) {
+	nan := math.NaN()
+	alerts := ExtendedAlerts{
+		{EvalMatches: []EvalMatch{{RefID: "A", Metric: "s1", Value: ptr(3)}}},
+		{EvalMatches: []EvalMatch{{RefID: "A", Metric: "s2", Value: ptr(9)}}},
+		{EvalMatches: []EvalMatch{{RefID: "A", Metric: "s3", Value: ptr(-2)}}},
+		{EvalMatches: []EvalMatch{{RefID: "A", Metric: "s4", Value: &nan}}},
+		{EvalMatches: []EvalMatch{{RefID: "B", Metric: "other", Value: ptr(100)}}},
+	}
+
+	top := alerts.TopValuesByRefID("A", 2)
+	require.Len(t, top, 2)
+	require.Equal(t, "s2", top[0].Metric)
+	require.Equal(t, "s1", top[1].Metric)
+}
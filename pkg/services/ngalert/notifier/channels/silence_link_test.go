@@ -0,0 +1,65 @@
+package channels
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/prometheus/alertmanager/template"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSilenceLinkBuilder_DefaultMatchesAllNonPrivateLabels(t *testing.T) {
+	b := SilenceLinkBuilder{}
+	labels := template.KV{"severity": "critical", "service": "api", "__alert_rule_uid__": "abc"}
+
+	matchers := b.matchers(labels)
+	require.Equal(t, []string{"service=api", "severity=critical"}, matchers)
+}
+
+func TestSilenceLinkBuilder_LabelOrderingIsStable(t *testing.T) {
+	b := SilenceLinkBuilder{}
+	labels := template.KV{"z": "1", "a": "2", "m": "3"}
+
+	matchers := b.matchers(labels)
+	require.Equal(t, []string{"a=2", "m=3", "z=1"}, matchers)
+}
+
+func TestSilenceLinkBuilder_AllowDenyLists(t *testing.T) {
+	labels := template.KV{"service": "api", "severity": "critical", "alertname": "HighCPU"}
+
+	allow := SilenceLinkBuilder{AllowLabels: []string{"service", "severity"}}
+	require.Equal(t, []string{"service=api", "severity=critical"}, allow.matchers(labels))
+
+	deny := SilenceLinkBuilder{DenyLabels: []string{"alertname"}}
+	require.Equal(t, []string{"service=api", "severity=critical"}, deny.matchers(labels))
+}
+
+func TestSilenceLinkBuilder_RegexOperator(t *testing.T) {
+	b := SilenceLinkBuilder{
+		AllowLabels: []string{"instance"},
+		Operators:   map[string]MatcherOperator{"instance": MatchRegexp},
+	}
+	labels := template.KV{"instance": "web-.*"}
+
+	require.Equal(t, []string{"instance=~web-.*"}, b.matchers(labels))
+}
+
+func TestSilenceLinkBuilder_QueryValuesEscapesSpecialChars(t *testing.T) {
+	b := SilenceLinkBuilder{Comment: "scoped to on-call, ack'd"}
+	labels := template.KV{"service": `a,b"c`}
+
+	q := b.queryValues(labels)
+	require.Equal(t, []string{defaultAlertmanagerIdentifier}, q["alertmanager"])
+	require.Equal(t, []string{`service=a,b"c`}, q["matcher"])
+	require.Equal(t, []string{"scoped to on-call, ack'd"}, q["comment"])
+
+	encoded := q.Encode()
+	decoded, err := url.ParseQuery(encoded)
+	require.NoError(t, err)
+	require.Equal(t, q, decoded)
+}
+
+func TestSilenceLinkBuilder_CustomAlertmanagerIdentifier(t *testing.T) {
+	b := SilenceLinkBuilder{AlertmanagerIdentifier: "mimir"}
+	require.Equal(t, []string{"mimir"}, b.queryValues(template.KV{})["alertmanager"])
+}
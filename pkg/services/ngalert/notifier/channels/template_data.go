@@ -2,10 +2,8 @@ package channels
 
 import (
 	"context"
-	"encoding/json"
 	"net/url"
 	"path"
-	"sort"
 	"strings"
 	"time"
 
@@ -18,16 +16,19 @@ import (
 )
 
 type ExtendedAlert struct {
-	Status        string             `json:"status"`
-	Labels        template.KV        `json:"labels"`
-	Annotations   template.KV        `json:"annotations"`
-	StartsAt      time.Time          `json:"startsAt"`
-	EndsAt        time.Time          `json:"endsAt"`
-	GeneratorURL  string             `json:"generatorURL"`
-	Fingerprint   string             `json:"fingerprint"`
-	SilenceURL    string             `json:"silenceURL"`
-	DashboardURL  string             `json:"dashboardURL"`
-	PanelURL      string             `json:"panelURL"`
+	Status       string      `json:"status"`
+	Labels       template.KV `json:"labels"`
+	Annotations  template.KV `json:"annotations"`
+	StartsAt     time.Time   `json:"startsAt"`
+	EndsAt       time.Time   `json:"endsAt"`
+	GeneratorURL string      `json:"generatorURL"`
+	Fingerprint  string      `json:"fingerprint"`
+	SilenceURL   string      `json:"silenceURL"`
+	DashboardURL string      `json:"dashboardURL"`
+	PanelURL     string      `json:"panelURL"`
+	// EvalMatches is the stable, typed view of a rule's per-series results.
+	// Values is kept for back-compat and is now derived from EvalMatches.
+	EvalMatches   []EvalMatch        `json:"evalMatches"`
 	Values        map[string]float64 `json:"values"`
 	ValueString   string             `json:"valueString"` // TODO: Remove in Grafana 10
 	ImageURL      string             `json:"imageURL,omitempty"`
@@ -46,6 +47,12 @@ type ExtendedData struct {
 	CommonAnnotations template.KV `json:"commonAnnotations"`
 
 	ExternalURL string `json:"externalURL"`
+
+	// Query runs a PromQL expression against this render's configured
+	// datasource, e.g. {{ call .Query "up" | first | value }}. Nil if no
+	// TemplateQuerier was supplied (via WithQuerier) - templates should
+	// guard with {{ if .Query }} before calling it.
+	Query QueryFunc `json:"-"`
 }
 
 func removePrivateItems(kv template.KV) template.KV {
@@ -57,7 +64,7 @@ func removePrivateItems(kv template.KV) template.KV {
 	return kv
 }
 
-func extendAlert(alert template.Alert, externalURL string, logger Logger) *ExtendedAlert {
+func extendAlert(alert template.Alert, externalURL string, logger Logger, silenceBuilder SilenceLinkBuilder) *ExtendedAlert {
 	// remove "private" annotations & labels so they don't show up in the template
 	extended := &ExtendedAlert{
 		Status:       alert.Status,
@@ -110,31 +117,14 @@ func extendAlert(alert template.Alert, externalURL string, logger Logger) *Exten
 	}
 
 	if alert.Annotations != nil {
-		if s, ok := alert.Annotations[ngmodels.ValuesAnnotation]; ok {
-			if err := json.Unmarshal([]byte(s), &extended.Values); err != nil {
-				logger.Warn("failed to unmarshal values annotation", "error", err)
-			}
-		}
+		extended.EvalMatches = parseEvalMatches(alert.Annotations, logger)
+		extended.Values = valuesFromEvalMatches(extended.EvalMatches)
 		// TODO: Remove in Grafana 10
 		extended.ValueString = alert.Annotations[ngmodels.ValueStringAnnotation]
 	}
 
-	matchers := make([]string, 0)
-	for key, value := range alert.Labels {
-		if !(strings.HasPrefix(key, "__") && strings.HasSuffix(key, "__")) {
-			matchers = append(matchers, key+"="+value)
-		}
-	}
-	sort.Strings(matchers)
 	u.Path = path.Join(externalPath, "/alerting/silence/new")
-
-	query := make(url.Values)
-	query.Add("alertmanager", "grafana")
-	for _, matcher := range matchers {
-		query.Add("matcher", matcher)
-	}
-
-	u.RawQuery = query.Encode()
+	u.RawQuery = silenceBuilder.queryValues(alert.Labels).Encode()
 
 	extended.SilenceURL = u.String()
 
@@ -149,11 +139,55 @@ func setOrgIdQueryParam(url *url.URL, orgId string) string {
 	return url.String()
 }
 
-func ExtendData(data *template.Data, logger Logger) *ExtendedData {
+// extendDataOptions holds the optional knobs ExtendData/TmplText accept.
+// It's built up via ExtendDataOption so new knobs can be added without
+// breaking existing callers' positional argument lists.
+type extendDataOptions struct {
+	silenceBuilder *SilenceLinkBuilder
+	ctx            context.Context
+	querier        TemplateQuerier
+}
+
+type ExtendDataOption func(*extendDataOptions)
+
+// WithSilenceLinkBuilder controls how each alert's SilenceURL is constructed.
+// Without it, ExtendData falls back to the default, back-compat behavior
+// (every non-private label, exact-match, against the "grafana" Alertmanager).
+func WithSilenceLinkBuilder(silenceBuilder *SilenceLinkBuilder) ExtendDataOption {
+	return func(o *extendDataOptions) {
+		o.silenceBuilder = silenceBuilder
+	}
+}
+
+// WithQuerier binds querier into the returned ExtendedData.Query for this
+// render; ctx bounds how long any .Query call is allowed to run. Without it,
+// ExtendedData.Query is left nil.
+func WithQuerier(ctx context.Context, querier TemplateQuerier) ExtendDataOption {
+	return func(o *extendDataOptions) {
+		o.ctx = ctx
+		o.querier = querier
+	}
+}
+
+// ExtendData builds an ExtendedData from the Alertmanager template.Data. See
+// WithSilenceLinkBuilder and WithQuerier for the available opts; both are
+// optional so existing callers keep compiling as new knobs are added.
+func ExtendData(data *template.Data, logger Logger, opts ...ExtendDataOption) *ExtendedData {
+	options := extendDataOptions{}
+	for _, opt := range opts {
+		opt(&options)
+	}
+	if options.silenceBuilder == nil {
+		options.silenceBuilder = &SilenceLinkBuilder{}
+	}
+	if options.ctx == nil {
+		options.ctx = context.Background()
+	}
+
 	alerts := []ExtendedAlert{}
 
 	for _, alert := range data.Alerts {
-		extendedAlert := extendAlert(alert, data.ExternalURL, logger)
+		extendedAlert := extendAlert(alert, data.ExternalURL, logger, *options.silenceBuilder)
 		alerts = append(alerts, *extendedAlert)
 	}
 
@@ -167,20 +201,53 @@ func ExtendData(data *template.Data, logger Logger) *ExtendedData {
 
 		ExternalURL: data.ExternalURL,
 	}
+	if options.querier != nil {
+		extended.Query = newQueryFunc(options.ctx, options.querier)
+	}
 	return extended
 }
 
-func TmplText(ctx context.Context, tmpl *template.Template, alerts []*types.Alert, l Logger, tmplErr *error) (func(string) string, *ExtendedData) {
+// NewTemplate parses the notification template files at paths and installs
+// TemplateFuncMap on the result. Callers that load notification templates
+// (e.g. when a receiver's config changes) should construct their
+// *template.Template through this function rather than template.FromGlobs
+// directly: text/template resolves function identifiers at Parse time, so
+// the FuncMap has to be in place before any template text is parsed into it.
+// The files at paths are parsed by template.FromGlobs before the FuncMap is
+// installed, so they can only use the Alertmanager default funcs; text parsed
+// into the returned template afterwards (e.g. tmpl.Text.New(name).Parse) can
+// use TemplateFuncMap as well.
+func NewTemplate(paths ...string) (*template.Template, error) {
+	tmpl, err := template.FromGlobs(paths...)
+	if err != nil {
+		return nil, err
+	}
+	tmpl.Funcs(TemplateFuncMap())
+	return tmpl, nil
+}
+
+// TmplText mirrors ExtendData's opts - see WithSilenceLinkBuilder and
+// WithQuerier - so existing callers keep compiling as new knobs are added.
+func TmplText(ctx context.Context, tmpl *template.Template, alerts []*types.Alert, l Logger, tmplErr *error, opts ...ExtendDataOption) (func(string) string, *ExtendedData) {
 	promTmplData := notify.GetTemplateData(ctx, tmpl, alerts, l)
-	data := ExtendData(promTmplData, l)
+	data := ExtendData(promTmplData, l, opts...)
 
+	return RenderText(tmpl, data, tmplErr), data
+}
+
+// RenderText returns a function that executes named templates against data,
+// recording the first error into tmplErr. tmpl must already have
+// TemplateFuncMap installed (see NewTemplate) - RenderText only executes,
+// it doesn't register funcs, since by render time the template has already
+// been parsed and tmpl.Funcs would be too late to affect it.
+func RenderText(tmpl *template.Template, data *ExtendedData, tmplErr *error) func(string) string {
 	return func(name string) (s string) {
 		if *tmplErr != nil {
 			return
 		}
 		s, *tmplErr = tmpl.ExecuteTextString(name, data)
 		return s
-	}, data
+	}
 }
 
 // Firing returns the subset of alerts that are firing.
@@ -0,0 +1,226 @@
+package channels
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strings"
+	"sync"
+	"sync/atomic"
+	text_template "text/template"
+	"time"
+
+	"github.com/prometheus/alertmanager/template"
+)
+
+// TemplateFuncMap returns the set of functions made available to every
+// notification template, in addition to whatever funcs the Alertmanager base
+// template already registers. Keeping them in one place means every notifier
+// gets the same vocabulary regardless of which channel it goes out through.
+//
+// text/template resolves function identifiers at Parse time, not Execute
+// time, so this must be installed on a *template.Template with tmpl.Funcs(...)
+// before the notification templates are parsed (see NewTemplate) - calling it
+// later, e.g. right before rendering, is too late and leaves every func
+// reported as "not defined".
+func TemplateFuncMap() text_template.FuncMap {
+	return text_template.FuncMap{
+		"toUpper":           strings.ToUpper,
+		"toLower":           strings.ToLower,
+		"title":             strings.Title, //nolint:staticcheck // matches Alertmanager's own template funcs
+		"trimSpace":         strings.TrimSpace,
+		"join":              strings.Join,
+		"split":             strings.Split,
+		"replace":           replaceAll,
+		"reReplaceAll":      reReplaceAll,
+		"humanize":          humanize,
+		"humanize1024":      humanize1024,
+		"humanizeDuration":  humanizeDuration,
+		"humanizeTimestamp": humanizeTimestamp,
+		"stripLabels":       stripLabels,
+		"keepLabels":        keepLabels,
+		"first":             first,
+		"value":             value,
+		"label":             label,
+		"strvalue":          strvalue,
+		"sortByLabel":       sortByLabel,
+	}
+}
+
+// replaceAll mirrors strings.ReplaceAll but with the arguments in the order
+// that's convenient to pipe into from a template: {{ .Value | replace "," "" }}.
+func replaceAll(old, new, s string) string {
+	return strings.ReplaceAll(s, old, new)
+}
+
+// reReplaceAllCache holds one compiled *regexp.Regexp per distinct pattern
+// string seen across all templates, so a pattern used in a loop (e.g. over
+// .Alerts) or reused across renders is compiled once, not per call. It's
+// capped at reReplaceAllCacheMax entries: notification templates are
+// user-authored per-org, so an unbounded cache would let a pattern built
+// from varying alert data (e.g. a label value) grow this without limit for
+// the life of the process. Once full, new patterns are compiled uncached
+// rather than evicting - a bounded, cheap-to-reason-about trade-off, since
+// the set of patterns actually written into a template's text is normally
+// tiny and stable.
+var reReplaceAllCache sync.Map // pattern string -> *regexp.Regexp (or a cached compile error)
+
+var reReplaceAllCacheSize int64
+
+const reReplaceAllCacheMax = 1000
+
+type cachedRegexp struct {
+	re  *regexp.Regexp
+	err error
+}
+
+func compileCachedRegexp(pattern string) (*regexp.Regexp, error) {
+	if v, ok := reReplaceAllCache.Load(pattern); ok {
+		c := v.(cachedRegexp)
+		return c.re, c.err
+	}
+	re, err := regexp.Compile(pattern)
+	if atomic.LoadInt64(&reReplaceAllCacheSize) >= reReplaceAllCacheMax {
+		return re, err
+	}
+	if _, loaded := reReplaceAllCache.LoadOrStore(pattern, cachedRegexp{re: re, err: err}); !loaded {
+		atomic.AddInt64(&reReplaceAllCacheSize, 1)
+	}
+	return re, err
+}
+
+// reReplaceAll replaces all matches of the regex pattern in s with
+// replacement, supporting capture group references (${1}, $name, ...).
+// Compiled patterns are cached (see reReplaceAllCache) since the same
+// pattern is typically reused across many alerts and many renders.
+func reReplaceAll(pattern, replacement, s string) (string, error) {
+	re, err := compileCachedRegexp(pattern)
+	if err != nil {
+		return "", fmt.Errorf("reReplaceAll: invalid pattern %q: %w", pattern, err)
+	}
+	return re.ReplaceAllString(s, replacement), nil
+}
+
+// humanize formats v using metric prefixes (K, M, G, ...), e.g. 1234 -> "1.234k".
+func humanize(v float64) (string, error) {
+	if math.IsNaN(v) || math.IsInf(v, 0) {
+		return fmt.Sprintf("%.4g", v), nil
+	}
+	if v == 0 {
+		return fmt.Sprintf("%.4g", v), nil
+	}
+	magnitude := 1.0
+	exponent := 0
+	absV := math.Abs(v)
+	for absV >= 1000 && exponent < 24 {
+		magnitude *= 1000
+		exponent += 3
+		absV /= 1000
+	}
+	for absV < 1 && exponent > -24 {
+		magnitude /= 1000
+		exponent -= 3
+		absV *= 1000
+	}
+	prefixes := map[int]string{
+		-24: "y", -21: "z", -18: "a", -15: "f", -12: "p", -9: "n", -6: "u", -3: "m",
+		0: "", 3: "k", 6: "M", 9: "G", 12: "T", 15: "P", 18: "E", 21: "Z", 24: "Y",
+	}
+	return fmt.Sprintf("%.4g%s", v/magnitude, prefixes[exponent]), nil
+}
+
+// humanize1024 is identical to humanize but scales by powers of 1024 instead
+// of 1000, for byte-like quantities.
+func humanize1024(v float64) (string, error) {
+	if math.IsNaN(v) || math.IsInf(v, 0) || math.Abs(v) < 1 {
+		return fmt.Sprintf("%.4g", v), nil
+	}
+	prefixes := []string{"", "Ki", "Mi", "Gi", "Ti", "Pi", "Ei", "Zi", "Yi"}
+	i := 0
+	for math.Abs(v) >= 1024 && i < len(prefixes)-1 {
+		v /= 1024
+		i++
+	}
+	return fmt.Sprintf("%.4g%s", v, prefixes[i]), nil
+}
+
+// humanizeDuration formats a duration given in seconds the way Alertmanager
+// / Prometheus templates traditionally do, e.g. 3661 -> "1h 1m 1s".
+func humanizeDuration(seconds float64) (string, error) {
+	if math.IsNaN(seconds) || math.IsInf(seconds, 0) {
+		return fmt.Sprintf("%.4g", seconds), nil
+	}
+	if seconds == 0 {
+		return "0s", nil
+	}
+	if math.Abs(seconds) < 1 {
+		return fmt.Sprintf("%.4gs", seconds), nil
+	}
+
+	sign := ""
+	if seconds < 0 {
+		sign = "-"
+		seconds = -seconds
+	}
+
+	d := int64(seconds)
+	years := d / (60 * 60 * 24 * 365)
+	d -= years * 60 * 60 * 24 * 365
+	days := d / (60 * 60 * 24)
+	d -= days * 60 * 60 * 24
+	hours := d / (60 * 60)
+	d -= hours * 60 * 60
+	minutes := d / 60
+	d -= minutes * 60
+	secs := d
+
+	parts := make([]string, 0, 5)
+	if years > 0 {
+		parts = append(parts, fmt.Sprintf("%dy", years))
+	}
+	if days > 0 {
+		parts = append(parts, fmt.Sprintf("%dd", days))
+	}
+	if hours > 0 {
+		parts = append(parts, fmt.Sprintf("%dh", hours))
+	}
+	if minutes > 0 {
+		parts = append(parts, fmt.Sprintf("%dm", minutes))
+	}
+	if secs > 0 || len(parts) == 0 {
+		parts = append(parts, fmt.Sprintf("%ds", secs))
+	}
+
+	return sign + strings.Join(parts, " "), nil
+}
+
+// humanizeTimestamp renders a Unix timestamp (in seconds, as used throughout
+// the Prometheus ecosystem) as RFC3339 in UTC.
+func humanizeTimestamp(ts float64) (string, error) {
+	if math.IsNaN(ts) || math.IsInf(ts, 0) {
+		return fmt.Sprintf("%.4g", ts), nil
+	}
+	t := time.Unix(0, int64(ts*float64(time.Second))).UTC()
+	return t.Format(time.RFC3339), nil
+}
+
+// stripLabels returns a copy of kv with the given label names removed, so
+// templates can iterate the remainder without the noisy ones.
+func stripLabels(kv template.KV, names ...string) template.KV {
+	return kv.Remove(names)
+}
+
+// keepLabels returns a copy of kv containing only the given label names.
+func keepLabels(kv template.KV, names ...string) template.KV {
+	keep := make(map[string]struct{}, len(names))
+	for _, n := range names {
+		keep[n] = struct{}{}
+	}
+	out := template.KV{}
+	for k, v := range kv {
+		if _, ok := keep[k]; ok {
+			out[k] = v
+		}
+	}
+	return out
+}
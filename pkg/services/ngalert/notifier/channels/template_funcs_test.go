@@ -0,0 +1,97 @@
+package channels
+
+import (
+	"testing"
+
+	"github.com/prometheus/alertmanager/template"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReplaceAll(t *testing.T) {
+	require.Equal(t, "foo-bar", replaceAll(",", "-", "foo,bar"))
+}
+
+func TestReReplaceAll(t *testing.T) {
+	out, err := reReplaceAll(`(\w+)@(\w+)`, "$1 at $2", "alice@example")
+	require.NoError(t, err)
+	require.Equal(t, "alice at example", out)
+
+	_, err = reReplaceAll(`(`, "", "foo")
+	require.Error(t, err)
+}
+
+func TestReReplaceAll_CachesCompiledPattern(t *testing.T) {
+	const pattern = `unique-pattern-for-caching-test-\d+`
+
+	_, ok := reReplaceAllCache.Load(pattern)
+	require.False(t, ok, "pattern should not be cached before first use")
+
+	_, err := reReplaceAll(pattern, "x", "unique-pattern-for-caching-test-1")
+	require.NoError(t, err)
+
+	v, ok := reReplaceAllCache.Load(pattern)
+	require.True(t, ok, "pattern should be cached after first use")
+	cached := v.(cachedRegexp).re
+
+	_, err = reReplaceAll(pattern, "x", "unique-pattern-for-caching-test-2")
+	require.NoError(t, err)
+
+	v, _ = reReplaceAllCache.Load(pattern)
+	require.Same(t, cached, v.(cachedRegexp).re, "second call should reuse the compiled regexp, not recompile it")
+}
+
+func TestHumanize(t *testing.T) {
+	cases := []struct {
+		in   float64
+		want string
+	}{
+		{0, "0"},
+		{1, "1"},
+		{1234, "1.234k"},
+		{1234567, "1.235M"},
+	}
+	for _, c := range cases {
+		got, err := humanize(c.in)
+		require.NoError(t, err)
+		require.Equal(t, c.want, got)
+	}
+}
+
+func TestHumanize1024(t *testing.T) {
+	got, err := humanize1024(1024 * 1024)
+	require.NoError(t, err)
+	require.Equal(t, "1Mi", got)
+}
+
+func TestHumanizeDuration(t *testing.T) {
+	cases := []struct {
+		in   float64
+		want string
+	}{
+		{0, "0s"},
+		{5, "5s"},
+		{65, "1m 5s"},
+		{3661, "1h 1m 1s"},
+	}
+	for _, c := range cases {
+		got, err := humanizeDuration(c.in)
+		require.NoError(t, err)
+		require.Equal(t, c.want, got)
+	}
+}
+
+func TestHumanizeTimestamp(t *testing.T) {
+	got, err := humanizeTimestamp(0)
+	require.NoError(t, err)
+	require.Equal(t, "1970-01-01T00:00:00Z", got)
+}
+
+func TestStripAndKeepLabels(t *testing.T) {
+	kv := template.KV{"severity": "critical", "service": "api", "region": "us"}
+
+	stripped := stripLabels(kv, "region")
+	require.Equal(t, template.KV{"severity": "critical", "service": "api"}, stripped)
+
+	kept := keepLabels(kv, "service")
+	require.Equal(t, template.KV{"service": "api"}, kept)
+}
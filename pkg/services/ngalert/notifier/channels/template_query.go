@@ -0,0 +1,98 @@
+package channels
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/prometheus/common/model"
+)
+
+// maxQuerySamples bounds how many series a single .Query call may return to
+// a template, so a broad selector can't blow up the size (or render time) of
+// a notification.
+const maxQuerySamples = 1000
+
+// defaultQueryTimeout bounds how long a single .Query call is allowed to
+// run, independent of how long the overall render is given by ctx.
+const defaultQueryTimeout = 10 * time.Second
+
+// Sample is a single result row of a PromQL query, as exposed to
+// notification templates.
+type Sample struct {
+	Labels model.LabelSet `json:"labels"`
+	Value  float64        `json:"value"`
+}
+
+// TemplateQuerier lets notification templates pull live data from a
+// datasource at render time, mirroring the template expander Prometheus
+// uses for recording/alerting rules.
+type TemplateQuerier interface {
+	Query(ctx context.Context, expr string, ts time.Time) ([]Sample, error)
+}
+
+// QueryFunc is the type of ExtendedData.Query. It's a field rather than a
+// FuncMap entry: the query/first/value family needs a per-render ctx and
+// querier, but a notification template's *template.Template is parsed once
+// and reused (concurrently) across many renders, so it can't carry per-call
+// state via tmpl.Funcs without racing. Binding the closure into the
+// per-render ExtendedData value instead means each render gets its own,
+// unshared binding - a template calls it as "{{ call .Query \"up\" | first | value }}".
+type QueryFunc func(expr string) ([]Sample, error)
+
+// newQueryFunc returns the QueryFunc bound to querier and ctx for a single
+// render. If querier is nil, the returned func errors instead of panicking.
+// ExtendData only binds it when a querier was given; otherwise
+// ExtendedData.Query is nil.
+func newQueryFunc(ctx context.Context, querier TemplateQuerier) QueryFunc {
+	return func(expr string) ([]Sample, error) {
+		if querier == nil {
+			return nil, fmt.Errorf("query: no datasource configured for this notification")
+		}
+
+		qCtx, cancel := context.WithTimeout(ctx, defaultQueryTimeout)
+		defer cancel()
+
+		samples, err := querier.Query(qCtx, expr, time.Now())
+		if err != nil {
+			return nil, fmt.Errorf("query %q: %w", expr, err)
+		}
+		if len(samples) > maxQuerySamples {
+			samples = samples[:maxQuerySamples]
+		}
+		return samples, nil
+	}
+}
+
+// first, value, label, strvalue, and sortByLabel are pure functions of their
+// arguments, so unlike query they carry no per-render state and can live in
+// the parse-time TemplateFuncMap safely shared across concurrent renders.
+
+func first(samples []Sample) (Sample, error) {
+	if len(samples) == 0 {
+		return Sample{}, fmt.Errorf("first: no results")
+	}
+	return samples[0], nil
+}
+
+func value(s Sample) float64 {
+	return s.Value
+}
+
+func label(name string, s Sample) string {
+	return string(s.Labels[model.LabelName(name)])
+}
+
+func strvalue(s Sample) string {
+	return fmt.Sprintf("%g", s.Value)
+}
+
+func sortByLabel(name string, samples []Sample) []Sample {
+	sorted := make([]Sample, len(samples))
+	copy(sorted, samples)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Labels[model.LabelName(name)] < sorted[j].Labels[model.LabelName(name)]
+	})
+	return sorted
+}
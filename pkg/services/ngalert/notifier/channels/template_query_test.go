@@ -0,0 +1,88 @@
+package channels
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/prometheus/alertmanager/template"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeQuerier struct {
+	samples []Sample
+	err     error
+}
+
+func (f *fakeQuerier) Query(_ context.Context, _ string, _ time.Time) ([]Sample, error) {
+	return f.samples, f.err
+}
+
+func TestNewQueryFunc(t *testing.T) {
+	t.Run("no querier configured", func(t *testing.T) {
+		q := newQueryFunc(context.Background(), nil)
+		_, err := q("up")
+		require.Error(t, err)
+	})
+
+	t.Run("propagates querier error", func(t *testing.T) {
+		q := newQueryFunc(context.Background(), &fakeQuerier{err: errors.New("boom")})
+		_, err := q("up")
+		require.ErrorContains(t, err, "boom")
+	})
+
+	t.Run("caps result cardinality", func(t *testing.T) {
+		samples := make([]Sample, maxQuerySamples+10)
+		q := newQueryFunc(context.Background(), &fakeQuerier{samples: samples})
+		got, err := q("up")
+		require.NoError(t, err)
+		require.Len(t, got, maxQuerySamples)
+	})
+
+	t.Run("two renders get independent bindings", func(t *testing.T) {
+		q1 := newQueryFunc(context.Background(), &fakeQuerier{samples: []Sample{{Value: 1}}})
+		q2 := newQueryFunc(context.Background(), &fakeQuerier{samples: []Sample{{Value: 2}}})
+
+		got1, err := q1("up")
+		require.NoError(t, err)
+		got2, err := q2("up")
+		require.NoError(t, err)
+
+		require.Equal(t, float64(1), got1[0].Value)
+		require.Equal(t, float64(2), got2[0].Value)
+	})
+}
+
+func TestExtendData_QueryNilWithoutQuerier(t *testing.T) {
+	data := ExtendData(&template.Data{}, fakeLogger{})
+	require.Nil(t, data.Query)
+
+	data = ExtendData(&template.Data{}, fakeLogger{}, WithQuerier(context.Background(), &fakeQuerier{samples: []Sample{{Value: 1}}}))
+	require.NotNil(t, data.Query)
+}
+
+func TestFirstValueLabelStrvalue(t *testing.T) {
+	_, err := first(nil)
+	require.Error(t, err)
+
+	s := Sample{Labels: model.LabelSet{"service": "api"}, Value: 4.2}
+	got, err := first([]Sample{s})
+	require.NoError(t, err)
+	require.Equal(t, s, got)
+
+	require.Equal(t, 4.2, value(s))
+	require.Equal(t, "api", label("service", s))
+	require.Equal(t, "4.2", strvalue(s))
+}
+
+func TestSortByLabel(t *testing.T) {
+	samples := []Sample{
+		{Labels: model.LabelSet{"service": "b"}},
+		{Labels: model.LabelSet{"service": "a"}},
+	}
+	sorted := sortByLabel("service", samples)
+	require.Equal(t, model.LabelValue("a"), sorted[0].Labels["service"])
+	require.Equal(t, model.LabelValue("b"), sorted[1].Labels["service"])
+}